@@ -2,6 +2,7 @@ package frdrpc
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -9,55 +10,88 @@ import (
 	"github.com/lightninglabs/faraday/recommend"
 )
 
+// metricIDs maps the recommendation request's metric enum to the ID of the
+// recommender registered for it. New recommenders are picked up by adding
+// an entry here and a corresponding enum value, rather than by touching the
+// parsing logic below.
+var metricIDs = map[CloseRecommendationRequest_Metric]string{
+	CloseRecommendationRequest_UPTIME:           "uptime",
+	CloseRecommendationRequest_REVENUE:          "revenue",
+	CloseRecommendationRequest_INCOMING_VOLUME:  "incoming_volume",
+	CloseRecommendationRequest_OUTGOING_VOLUME:  "outgoing_volume",
+	CloseRecommendationRequest_TOTAL_VOLUME:     "total_volume",
+	CloseRecommendationRequest_FEE_EARNINGS_SAT: "fee_earnings_per_sat",
+	CloseRecommendationRequest_FLOW_BALANCE:     "flow_balance",
+	CloseRecommendationRequest_PEER_RELIABILITY: "peer_reliability",
+}
+
 // parseRecommendationRequest parses a close recommendation request and
 // returns the config required to get recommendations.
 func parseRecommendationRequest(ctx context.Context, cfg *Config,
-	req *CloseRecommendationRequest) *recommend.CloseRecommendationConfig {
+	req *CloseRecommendationRequest) (*recommend.CloseRecommendationConfig,
+	error) {
+
+	id, ok := metricIDs[req.Metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric: %v", req.Metric)
+	}
+
+	metric, err := recommend.GetRecommender(id)
+	if err != nil {
+		return nil, err
+	}
+
+	maxStaleness := time.Second * time.Duration(req.MaxStaleness)
 
 	// Create a close recommendations config with the minimum monitored
-	// value provided in the request and the default outlier multiplier.
+	// value provided in the request and the requested recommender.
 	recCfg := &recommend.CloseRecommendationConfig{
 		ChannelInsights: func() ([]*insights.ChannelInfo, error) {
-			return channelInsights(ctx, cfg)
+			return channelInsights(ctx, cfg, maxStaleness)
 		},
 		MinimumMonitored: time.Second *
 			time.Duration(req.MinimumMonitored),
+		Metric: metric,
 	}
 
-	// Get the metric that the recommendations are being calculated based
-	// on.
-	switch req.Metric {
-	case CloseRecommendationRequest_UPTIME:
-		recCfg.Metric = recommend.UptimeMetric
-
-	case CloseRecommendationRequest_REVENUE:
-		recCfg.Metric = recommend.RevenueMetric
-
-	case CloseRecommendationRequest_INCOMING_VOLUME:
-		recCfg.Metric = recommend.IncomingVolume
-
-	case CloseRecommendationRequest_OUTGOING_VOLUME:
-		recCfg.Metric = recommend.OutgoingVolume
+	return recCfg, nil
+}
 
-	case CloseRecommendationRequest_TOTAL_VOLUME:
-		recCfg.Metric = recommend.Volume
+// listRecommenders returns the set of recommenders currently registered,
+// for use by the ListRecommenders rpc.
+func listRecommenders() *ListRecommendersResponse {
+	resp := &ListRecommendersResponse{}
+
+	for _, r := range recommend.ListRecommenders() {
+		resp.Recommenders = append(
+			resp.Recommenders, &RecommenderInfo{
+				Id:             r.ID(),
+				Description:    r.Description(),
+				CloseableAbove: r.CloseableAbove(),
+			},
+		)
 	}
 
-	return recCfg
+	return resp
 }
 
 // parseOutlierRequest parses a rpc outlier recommendation request and returns
 // the close recommendation config and multiplier required.
 func parseOutlierRequest(ctx context.Context, cfg *Config,
 	req *OutlierRecommendationsRequest) (
-	*recommend.CloseRecommendationConfig, float64) {
+	*recommend.CloseRecommendationConfig, float64, error) {
 
 	multiplier := recommend.DefaultOutlierMultiplier
 	if req.OutlierMultiplier != 0 {
 		multiplier = float64(req.OutlierMultiplier)
 	}
 
-	return parseRecommendationRequest(ctx, cfg, req.RecRequest), multiplier
+	recCfg, err := parseRecommendationRequest(ctx, cfg, req.RecRequest)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return recCfg, multiplier, nil
 }
 
 // parseThresholdRequest parses a rpc threshold recommendation request and
@@ -66,10 +100,14 @@ func parseOutlierRequest(ctx context.Context, cfg *Config,
 // a default that returns values below a threshold.
 func parseThresholdRequest(ctx context.Context, cfg *Config,
 	req *ThresholdRecommendationsRequest) (
-	*recommend.CloseRecommendationConfig, float64) {
+	*recommend.CloseRecommendationConfig, float64, error) {
+
+	recCfg, err := parseRecommendationRequest(ctx, cfg, req.RecRequest)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return parseRecommendationRequest(ctx, cfg, req.RecRequest),
-		float64(req.ThresholdValue)
+	return recCfg, float64(req.ThresholdValue), nil
 }
 
 // rpcResponse parses the response obtained getting a close recommendation
@@ -0,0 +1,46 @@
+package frdrpc
+
+import (
+	"time"
+
+	"github.com/lightninglabs/faraday/frdrpc/limithandler"
+	"github.com/lightninglabs/faraday/frdrpc/lndpool"
+	"github.com/lightninglabs/faraday/insights"
+	"github.com/lightninglabs/faraday/recstore"
+)
+
+// Config provides closures and settings required to run the rpc server.
+type Config struct {
+	// LndPool provides access to the set of lnd backends that faraday
+	// reads channel and forwarding data from. Read requests are routed
+	// to whichever backend in the pool is currently in sync, and are
+	// retried against another backend if the chosen one errors out.
+	LndPool *lndpool.Pool
+
+	// InsightsCache caches the result of walking lnd's forwarding
+	// history and channel graph, so that back-to-back recommendation
+	// requests do not each trigger a fresh, expensive scan.
+	InsightsCache *insights.Cache
+
+	// Monitor accumulates the peer uptime, disconnect, HTLC and
+	// forwarding statistics that cannot be read off a single
+	// point-in-time lnd query, fed by StartMonitor at startup.
+	Monitor *insights.Monitor
+
+	// RateLimit configures the concurrency and rate limits applied to
+	// the recommendation RPCs, which each trigger a potentially
+	// expensive channelInsights computation against lnd.
+	RateLimit limithandler.Config
+
+	// RecommendationStore persists a history of recommendation runs, if
+	// configured. Callers opt into persisting a given run via the
+	// SaveSnapshot field on the relevant recommendation request.
+	RecommendationStore *recstore.Store
+}
+
+// DefaultRecommendationMethodLimit is the per-method limit applied to each
+// of the recommendation RPCs when an operator has not overridden it.
+var DefaultRecommendationMethodLimit = limithandler.MethodLimit{
+	MaxConcurrency: 10,
+	MaxQueueWait:   5 * time.Second,
+}
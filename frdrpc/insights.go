@@ -0,0 +1,100 @@
+package frdrpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lightninglabs/faraday/insights"
+	"github.com/lightninglabs/lndclient"
+)
+
+// channelInsights returns the set of channel insights required to compute
+// close recommendations, consulting the configured insights cache first if
+// maxStaleness is non-zero. On a cache miss, or if no cache is configured,
+// the insights are fetched fresh from the lnd backend pool.
+func channelInsights(ctx context.Context, cfg *Config,
+	maxStaleness time.Duration) ([]*insights.ChannelInfo, error) {
+
+	fetch := func() ([]*insights.ChannelInfo, error) {
+		return fetchChannelInsights(ctx, cfg, cfg.Monitor)
+	}
+
+	if cfg.InsightsCache == nil {
+		return fetch()
+	}
+
+	return cfg.InsightsCache.Get(maxStaleness)
+}
+
+// fetchChannelInsights queries the configured lnd backend pool for the set
+// of channel insights required to compute close recommendations. Requests
+// are routed to any in-sync backend in the pool, falling back to another
+// backend if the first one queried errors out.
+func fetchChannelInsights(ctx context.Context, cfg *Config,
+	monitor *insights.Monitor) ([]*insights.ChannelInfo, error) {
+
+	var channelInfo []*insights.ChannelInfo
+
+	err := cfg.LndPool.Do(ctx, func(lnd *lndclient.LndServices) error {
+		info, err := getChannelInsights(ctx, lnd, monitor)
+		if err != nil {
+			return err
+		}
+
+		channelInfo = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel insights: %v",
+			err)
+	}
+
+	return channelInfo, nil
+}
+
+// getChannelInsights gathers the channel graph data and the accumulated
+// forwarding history / channel event data tracked by monitor to produce a
+// ChannelInfo for each of our currently open channels, using a single lnd
+// backend.
+func getChannelInsights(ctx context.Context, lnd *lndclient.LndServices,
+	monitor *insights.Monitor) ([]*insights.ChannelInfo, error) {
+
+	channels, err := lnd.Client.ListChannels(ctx, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var channelInfo []*insights.ChannelInfo
+	for _, channel := range channels {
+		peer := channel.PubKeyBytes.String()
+
+		info := &insights.ChannelInfo{
+			ChannelPoint: channel.ChannelPoint,
+			PubKey:       peer,
+			Capacity:     uint64(channel.Capacity),
+		}
+
+		if monitor != nil {
+			// Make sure the channel is tracked even if it opened
+			// after StartMonitor's initial registration pass.
+			monitor.TrackChannel(
+				channel.ChannelPoint, channel.ChannelID, peer,
+			)
+
+			snapshot := monitor.Snapshot(channel.ChannelPoint)
+			info.MonitoredSeconds = snapshot.MonitoredSeconds
+			info.UptimeSeconds = snapshot.UptimeSeconds
+			info.DisconnectCount = snapshot.DisconnectCount
+			info.HTLCTotalCount = snapshot.HTLCTotalCount
+			info.HTLCFailCount = snapshot.HTLCFailCount
+			info.VolumeIncomingMsat = snapshot.VolumeIncomingMsat
+			info.VolumeOutgoingMsat = snapshot.VolumeOutgoingMsat
+			info.FeesEarnedMsat = snapshot.FeesEarnedMsat
+		}
+
+		channelInfo = append(channelInfo, info)
+	}
+
+	return channelInfo, nil
+}
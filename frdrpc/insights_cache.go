@@ -0,0 +1,18 @@
+package frdrpc
+
+// insightsCacheStatusResponse converts the configured insights cache's
+// current status into its rpc representation. It returns an empty,
+// zero-valued response if no cache is configured.
+func insightsCacheStatusResponse(cfg *Config) *GetInsightsCacheStatusResponse {
+	if cfg.InsightsCache == nil {
+		return &GetInsightsCacheStatusResponse{}
+	}
+
+	status := cfg.InsightsCache.Status()
+
+	return &GetInsightsCacheStatusResponse{
+		Hits:       status.Hits,
+		Misses:     status.Misses,
+		AgeSeconds: uint64(status.Age.Seconds()),
+	}
+}
@@ -0,0 +1,37 @@
+package frdrpc
+
+import (
+	"github.com/lightninglabs/faraday/frdrpc/limithandler"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recommendationMethods lists the fully qualified gRPC methods that trigger
+// a channelInsights computation against lnd, and therefore need concurrency
+// and rate limiting applied to them.
+var recommendationMethods = []string{
+	"/frdrpc.FaradayServer/OutlierRecommendations",
+	"/frdrpc.FaradayServer/ThresholdRecommendations",
+	"/frdrpc.FaradayServer/MultiMetricRecommendations",
+}
+
+// newLimiter builds a limithandler.Limiter for the recommendation RPCs,
+// applying cfg.RateLimit's global/per-peer settings and falling back to
+// DefaultRecommendationMethodLimit for any recommendation method that does
+// not have an explicit override configured.
+func newLimiter(cfg *Config, registerer prometheus.Registerer) *limithandler.Limiter {
+	limitCfg := cfg.RateLimit
+	if limitCfg.MethodLimits == nil {
+		limitCfg.MethodLimits = make(map[string]limithandler.MethodLimit)
+	}
+
+	for _, method := range recommendationMethods {
+		if _, ok := limitCfg.MethodLimits[method]; !ok {
+			limitCfg.MethodLimits[method] =
+				DefaultRecommendationMethodLimit
+		}
+	}
+
+	metrics := limithandler.NewMetrics(registerer)
+
+	return limithandler.New(limitCfg, metrics)
+}
@@ -0,0 +1,319 @@
+// Package limithandler provides a gRPC interceptor that caps the number of
+// in-flight and queued requests faraday will forward to lnd at once, so
+// that a misbehaving client cannot saturate either faraday or the
+// underlying lnd node. Its shape mirrors Gitaly's limithandler middleware:
+// a per-method concurrency limiter, a global in-flight cap, and an
+// optional per-peer token bucket, all backed by Prometheus metrics.
+package limithandler
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerIdleTimeout is how long a peer's token bucket is kept around without
+// being used before it is evicted from the limiter's peer map.
+const peerIdleTimeout = 10 * time.Minute
+
+// peerSweepInterval is how often the limiter scans for idle peer buckets
+// to evict.
+const peerSweepInterval = time.Minute
+
+// MethodLimit describes the concurrency and rate limits applied to a
+// single gRPC method.
+type MethodLimit struct {
+	// MaxConcurrency is the maximum number of requests for this method
+	// that may hold a concurrency slot at once. Requests queued waiting
+	// for a slot do not count toward it, and their number is not
+	// capped. A value of zero disables the per-method limit.
+	MaxConcurrency int
+
+	// MaxQueueWait is the longest a request will wait for a concurrency
+	// slot to free up before it is rejected with ResourceExhausted. A
+	// value of zero means requests do not queue at all; they are
+	// rejected immediately once MaxConcurrency is reached.
+	MaxQueueWait time.Duration
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// MethodLimits maps a fully qualified gRPC method name (as provided
+	// by grpc.UnaryServerInfo.FullMethod) to the limit applied to it.
+	MethodLimits map[string]MethodLimit
+
+	// MaxConcurrentRequests caps the total number of requests in flight
+	// across all limited methods, regardless of their per-method
+	// limits. A value of zero disables the global cap.
+	MaxConcurrentRequests int
+
+	// PerPeerRateLimit is the sustained number of requests per second
+	// permitted from a single peer, identified by its connection's
+	// remote IP address. A value of zero disables per-peer rate
+	// limiting.
+	PerPeerRateLimit float64
+
+	// PerPeerBurst is the size of each peer's token bucket: the most
+	// requests a peer may make in a single burst. A bucket of size zero
+	// admits no requests, so it must be at least one whenever
+	// PerPeerRateLimit is set.
+	PerPeerBurst int
+}
+
+// methodState tracks the in-flight/queued count for a single method.
+type methodState struct {
+	limit MethodLimit
+	sem   chan struct{}
+}
+
+// peerBucket is a single peer's token bucket, along with the last time it
+// was used, so that idle buckets can be swept out of the peer map.
+type peerBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter enforces the concurrency and rate limits described by a Config
+// via a gRPC unary server interceptor.
+type Limiter struct {
+	cfg Config
+
+	methods map[string]*methodState
+	global  chan struct{}
+
+	peerMu      sync.Mutex
+	peerLimiter map[string]*peerBucket
+
+	metrics *Metrics
+
+	quit chan struct{}
+}
+
+// New creates a Limiter from the given config, starting a background
+// sweep of idle per-peer buckets if per-peer rate limiting is enabled.
+func New(cfg Config, metrics *Metrics) *Limiter {
+	l := &Limiter{
+		cfg:         cfg,
+		methods:     make(map[string]*methodState),
+		peerLimiter: make(map[string]*peerBucket),
+		metrics:     metrics,
+		quit:        make(chan struct{}),
+	}
+
+	for method, limit := range cfg.MethodLimits {
+		state := &methodState{limit: limit}
+		if limit.MaxConcurrency > 0 {
+			state.sem = make(chan struct{}, limit.MaxConcurrency)
+		}
+
+		l.methods[method] = state
+	}
+
+	if cfg.MaxConcurrentRequests > 0 {
+		l.global = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+
+	if cfg.PerPeerRateLimit > 0 {
+		go l.sweepIdlePeers()
+	}
+
+	return l
+}
+
+// Stop terminates the limiter's background idle-peer sweep.
+func (l *Limiter) Stop() {
+	close(l.quit)
+}
+
+// sweepIdlePeers periodically evicts per-peer token buckets that have not
+// been used in over peerIdleTimeout, so that the peer map does not grow
+// unbounded as distinct remote addresses connect over time.
+func (l *Limiter) sweepIdlePeers() {
+	ticker := time.NewTicker(peerSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := time.Now().Add(-peerIdleTimeout)
+
+			l.peerMu.Lock()
+			for id, bucket := range l.peerLimiter {
+				if bucket.lastSeen.Before(cutoff) {
+					delete(l.peerLimiter, id)
+				}
+			}
+			l.peerMu.Unlock()
+
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that
+// enforces this Limiter's configured limits before invoking the handler.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		if err := l.acquirePeer(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		release, err := l.acquire(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+// acquirePeer enforces the per-peer token bucket rate limit, if one is
+// configured.
+func (l *Limiter) acquirePeer(ctx context.Context, method string) error {
+	if l.cfg.PerPeerRateLimit == 0 {
+		return nil
+	}
+
+	id := peerID(ctx)
+
+	l.peerMu.Lock()
+	bucket, ok := l.peerLimiter[id]
+	if !ok {
+		bucket = &peerBucket{
+			limiter: rate.NewLimiter(
+				rate.Limit(l.cfg.PerPeerRateLimit),
+				l.cfg.PerPeerBurst,
+			),
+		}
+		l.peerLimiter[id] = bucket
+	}
+	bucket.lastSeen = time.Now()
+	l.peerMu.Unlock()
+
+	if !bucket.limiter.Allow() {
+		l.metrics.observeRejected(method, "peer_rate_limited")
+		return status.Errorf(codes.ResourceExhausted,
+			"rate limit exceeded for peer %v", id)
+	}
+
+	return nil
+}
+
+// acquire reserves a concurrency slot for method, queueing for up to the
+// method's MaxQueueWait if no slot is immediately available. A
+// MaxQueueWait of zero does not queue at all: the request is rejected
+// immediately if no slot is free. It returns a function that must be
+// called to release the slot once the request has completed.
+func (l *Limiter) acquire(ctx context.Context,
+	method string) (func(), error) {
+
+	state, limited := l.methods[method]
+	if !limited {
+		return func() {}, nil
+	}
+
+	l.metrics.observeQueued(method)
+	defer l.metrics.observeDequeued(method)
+
+	noWait := state.limit.MaxQueueWait == 0
+
+	waitCtx := ctx
+	if !noWait {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(
+			ctx, state.limit.MaxQueueWait,
+		)
+		defer cancel()
+	}
+
+	if state.sem != nil {
+		if noWait {
+			select {
+			case state.sem <- struct{}{}:
+			default:
+				l.metrics.observeRejected(method, "queue_timeout")
+				return nil, status.Errorf(codes.ResourceExhausted,
+					"%v: too many in-flight requests", method)
+			}
+		} else {
+			select {
+			case state.sem <- struct{}{}:
+			case <-waitCtx.Done():
+				l.metrics.observeRejected(method, "queue_timeout")
+				return nil, status.Errorf(codes.ResourceExhausted,
+					"%v: too many in-flight requests", method)
+			}
+		}
+	}
+
+	if l.global != nil {
+		acquired := false
+		if noWait {
+			select {
+			case l.global <- struct{}{}:
+				acquired = true
+			default:
+			}
+		} else {
+			select {
+			case l.global <- struct{}{}:
+				acquired = true
+			case <-waitCtx.Done():
+			}
+		}
+
+		if !acquired {
+			if state.sem != nil {
+				<-state.sem
+			}
+
+			l.metrics.observeRejected(method, "queue_timeout")
+			return nil, status.Errorf(codes.ResourceExhausted,
+				"too many in-flight requests")
+		}
+	}
+
+	l.metrics.observeInFlight(method, 1)
+
+	return func() {
+		l.metrics.observeInFlight(method, -1)
+
+		if l.global != nil {
+			<-l.global
+		}
+		if state.sem != nil {
+			<-state.sem
+		}
+	}, nil
+}
+
+// peerID returns a best-effort identifier for the peer on the other end of
+// ctx's connection, falling back to "unknown" if none is available. It is
+// keyed on the peer's remote IP alone, not the full address: every new TCP
+// connection is assigned a fresh ephemeral port, so including the port
+// would hand a reconnecting client a brand new token bucket each time.
+func peerID(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
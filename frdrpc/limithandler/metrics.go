@@ -0,0 +1,72 @@
+package limithandler
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the Prometheus metrics exported by a Limiter.
+type Metrics struct {
+	inFlight *prometheus.GaugeVec
+	queued   *prometheus.GaugeVec
+	rejected *prometheus.CounterVec
+}
+
+// NewMetrics creates a Metrics and registers it with registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "faraday",
+			Subsystem: "rpc_limithandler",
+			Name:      "in_flight_requests",
+			Help: "Number of requests currently being " +
+				"processed, by method.",
+		}, []string{"method"}),
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "faraday",
+			Subsystem: "rpc_limithandler",
+			Name:      "queued_requests",
+			Help: "Number of requests currently queued " +
+				"waiting for a concurrency slot, by method.",
+		}, []string{"method"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "faraday",
+			Subsystem: "rpc_limithandler",
+			Name:      "rejected_requests_total",
+			Help:      "Number of requests rejected, by method and reason.",
+		}, []string{"method", "reason"}),
+	}
+
+	registerer.MustRegister(m.inFlight, m.queued, m.rejected)
+
+	return m
+}
+
+func (m *Metrics) observeInFlight(method string, delta float64) {
+	if m == nil {
+		return
+	}
+
+	m.inFlight.WithLabelValues(method).Add(delta)
+}
+
+func (m *Metrics) observeQueued(method string) {
+	if m == nil {
+		return
+	}
+
+	m.queued.WithLabelValues(method).Inc()
+}
+
+func (m *Metrics) observeDequeued(method string) {
+	if m == nil {
+		return
+	}
+
+	m.queued.WithLabelValues(method).Dec()
+}
+
+func (m *Metrics) observeRejected(method, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.rejected.WithLabelValues(method, reason).Inc()
+}
@@ -0,0 +1,332 @@
+// Package lndpool implements a pool of lnd backends that can be queried
+// as a single logical lnd connection. It is intended for operators who run
+// multiple redundant lnd nodes and want faraday to keep producing
+// recommendations even when one of those nodes is unavailable, still
+// syncing to chain, or otherwise lagging behind its peers.
+package lndpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lightninglabs/lndclient"
+)
+
+// ErrNoBackends is returned when a pool has no registered backends.
+var ErrNoBackends = errors.New("lndpool: no backends configured")
+
+// ErrNoHealthyBackends is returned when every backend in the pool is
+// currently marked unhealthy.
+var ErrNoHealthyBackends = errors.New("lndpool: no healthy backends " +
+	"available")
+
+// BackendConfig holds the connection details for a single lnd backend that
+// is added to a pool.
+type BackendConfig struct {
+	// Name uniquely identifies the backend within the pool, and is used
+	// to label metrics and log output.
+	Name string
+
+	// Services is the set of lndclient services used to talk to this
+	// backend.
+	Services *lndclient.LndServices
+
+	// Weight is used for weighted round-robin selection among healthy
+	// backends. A weight of zero is treated as one.
+	Weight int
+}
+
+// backendStatus tracks the liveness of a single backend as observed by the
+// pool's health checker.
+type backendStatus struct {
+	blockHeight uint32
+	synced      bool
+	lastSeen    time.Time
+	healthy     bool
+}
+
+// backend is a single lnd connection managed by a Pool, along with the
+// metrics and health state the pool tracks for it.
+type backend struct {
+	cfg BackendConfig
+
+	mu     sync.RWMutex
+	status backendStatus
+
+	successCount uint64
+	errorCount   uint64
+
+	// latencyTotal and latencySamples are used to compute a rolling
+	// average latency for the backend.
+	latencyTotal   int64
+	latencySamples uint64
+}
+
+// BackendMetrics is a point in time snapshot of a single backend's health
+// and request statistics.
+type BackendMetrics struct {
+	Name           string
+	Healthy        bool
+	BlockHeight    uint32
+	Synced         bool
+	LastSeen       time.Time
+	SuccessCount   uint64
+	ErrorCount     uint64
+	AverageLatency time.Duration
+}
+
+// Config holds the parameters used to construct a Pool.
+type Config struct {
+	// Backends is the set of lnd connections that make up the pool.
+	Backends []BackendConfig
+
+	// HealthCheckInterval is the frequency with which each backend's
+	// health is polled in the background.
+	HealthCheckInterval time.Duration
+
+	// MaxRetries is the number of additional backends that are tried,
+	// in order, before a request is considered to have failed.
+	MaxRetries int
+}
+
+// Pool fans reads out across a set of lnd backends, preferring backends
+// that are in sync and have recently responded, and falling back to other
+// backends when a request errors out.
+type Pool struct {
+	cfg Config
+
+	backends []*backend
+
+	// next is used to implement round-robin selection across healthy
+	// backends.
+	next uint64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPool creates a pool of lnd backends and starts its background health
+// checker. Stop must be called to release the health check goroutine.
+func NewPool(cfg Config) (*Pool, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, ErrNoBackends
+	}
+
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = time.Minute
+	}
+
+	backends := make([]*backend, len(cfg.Backends))
+	for i, bCfg := range cfg.Backends {
+		if bCfg.Weight == 0 {
+			bCfg.Weight = 1
+		}
+
+		backends[i] = &backend{
+			cfg: bCfg,
+			// Assume healthy until the first check proves
+			// otherwise, so that the pool is usable immediately
+			// on startup.
+			status: backendStatus{healthy: true},
+		}
+	}
+
+	p := &Pool{
+		cfg:      cfg,
+		backends: backends,
+		quit:     make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.healthCheckLoop()
+
+	return p, nil
+}
+
+// Stop shuts down the pool's background health checker.
+func (p *Pool) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// healthCheckLoop periodically refreshes the health status of every
+// backend in the pool.
+func (p *Pool) healthCheckLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	// Run an initial check immediately so that the pool does not need to
+	// wait a full interval before it has accurate health information.
+	p.checkAll()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkAll()
+
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// checkAll refreshes the health status of every backend in the pool.
+func (p *Pool) checkAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, b := range p.backends {
+		p.checkBackend(ctx, b)
+	}
+}
+
+// checkBackend queries a single backend's chain sync state and records the
+// result on the backend's status.
+func (p *Pool) checkBackend(ctx context.Context, b *backend) {
+	info, err := b.cfg.Services.Client.GetInfo(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.status.healthy = false
+		return
+	}
+
+	b.status.blockHeight = info.BlockHeight
+	b.status.synced = info.SyncedToChain
+	b.status.lastSeen = time.Now()
+	b.status.healthy = info.SyncedToChain
+}
+
+// healthyBackends returns the subset of the pool's backends that are
+// currently marked healthy, expanded according to their configured weight
+// so that weighted round-robin selection can index directly into the
+// result.
+func (p *Pool) healthyBackends() []*backend {
+	var healthy []*backend
+
+	for _, b := range p.backends {
+		b.mu.RLock()
+		ok := b.status.healthy
+		weight := b.cfg.Weight
+		b.mu.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		for i := 0; i < weight; i++ {
+			healthy = append(healthy, b)
+		}
+	}
+
+	return healthy
+}
+
+// selectBackend picks the next backend to try using weighted round-robin
+// selection across the currently healthy backends, skipping over any
+// backend whose name is present in exclude.
+func (p *Pool) selectBackend(exclude map[string]bool) (*backend, error) {
+	healthy := p.healthyBackends()
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyBackends
+	}
+
+	for i := 0; i < len(healthy); i++ {
+		idx := atomic.AddUint64(&p.next, 1) % uint64(len(healthy))
+		candidate := healthy[idx]
+
+		if !exclude[candidate.cfg.Name] {
+			return candidate, nil
+		}
+	}
+
+	return nil, ErrNoHealthyBackends
+}
+
+// Do executes fn against a healthy backend in the pool, retrying against a
+// different backend on error up to Config.MaxRetries times.
+func (p *Pool) Do(ctx context.Context,
+	fn func(*lndclient.LndServices) error) error {
+
+	exclude := make(map[string]bool)
+
+	var lastErr error
+	for attempt := 0; attempt <= p.cfg.MaxRetries; attempt++ {
+		b, err := p.selectBackend(exclude)
+		if err != nil {
+			if lastErr != nil {
+				return fmt.Errorf("all backends exhausted, "+
+					"last error: %v", lastErr)
+			}
+
+			return err
+		}
+
+		start := time.Now()
+		err = fn(b.cfg.Services)
+		p.recordResult(b, time.Since(start), err)
+
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		exclude[b.cfg.Name] = true
+	}
+
+	return fmt.Errorf("request failed after %d retries: %v",
+		p.cfg.MaxRetries, lastErr)
+}
+
+// recordResult updates a backend's success/error counters and rolling
+// latency average following a completed request.
+func (p *Pool) recordResult(b *backend, latency time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&b.errorCount, 1)
+	} else {
+		atomic.AddUint64(&b.successCount, 1)
+	}
+
+	atomic.AddInt64(&b.latencyTotal, int64(latency))
+	atomic.AddUint64(&b.latencySamples, 1)
+}
+
+// Metrics returns a point in time snapshot of every backend's health and
+// request statistics.
+func (p *Pool) Metrics() []BackendMetrics {
+	metrics := make([]BackendMetrics, len(p.backends))
+
+	for i, b := range p.backends {
+		b.mu.RLock()
+		status := b.status
+		b.mu.RUnlock()
+
+		samples := atomic.LoadUint64(&b.latencySamples)
+		var avgLatency time.Duration
+		if samples > 0 {
+			total := atomic.LoadInt64(&b.latencyTotal)
+			avgLatency = time.Duration(total / int64(samples))
+		}
+
+		metrics[i] = BackendMetrics{
+			Name:           b.cfg.Name,
+			Healthy:        status.healthy,
+			BlockHeight:    status.blockHeight,
+			Synced:         status.synced,
+			LastSeen:       status.lastSeen,
+			SuccessCount:   atomic.LoadUint64(&b.successCount),
+			ErrorCount:     atomic.LoadUint64(&b.errorCount),
+			AverageLatency: avgLatency,
+		}
+	}
+
+	return metrics
+}
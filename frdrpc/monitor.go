@@ -0,0 +1,208 @@
+package frdrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/lightninglabs/faraday/insights"
+	"github.com/lightninglabs/lndclient"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+)
+
+// forwardingPollInterval is how often we poll lnd's forwarding history to
+// feed the insights monitor, since lnd does not expose forwarding events as
+// a subscription.
+const forwardingPollInterval = time.Minute
+
+// forwardingHistoryPageSize is the number of forwarding events requested
+// per page when backfilling since the last poll.
+const forwardingHistoryPageSize = 100
+
+// StartMonitor registers every currently open channel with monitor and
+// launches the background goroutines that keep its peer uptime, HTLC and
+// forwarding volume statistics up to date for as long as ctx is valid.
+func StartMonitor(ctx context.Context, monitor *insights.Monitor,
+	lnd *lndclient.LndServices) error {
+
+	channels, err := lnd.Client.ListChannels(ctx, false, false)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		monitor.TrackChannel(
+			channel.ChannelPoint, channel.ChannelID,
+			channel.PubKeyBytes.String(),
+		)
+	}
+
+	go monitorPeerEvents(ctx, monitor, lnd)
+	go monitorHTLCEvents(ctx, monitor, lnd)
+	go pollForwardingHistory(ctx, monitor, lnd)
+
+	return nil
+}
+
+// monitorPeerEvents subscribes to lnd's peer online/offline events and
+// forwards them to monitor until ctx is done.
+func monitorPeerEvents(ctx context.Context, monitor *insights.Monitor,
+	lnd *lndclient.LndServices) {
+
+	events, errChan, err := lnd.Client.SubscribePeerEvents(ctx)
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if event.Online {
+				monitor.PeerOnline(event.PubKey.String())
+			} else {
+				monitor.PeerOffline(event.PubKey.String())
+			}
+
+		case <-errChan:
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// monitorHTLCEvents subscribes to lnd's HTLC event stream and records the
+// outcome of every resolved HTLC against the channel it used, so that
+// peerReliabilityRecommender has a non-zero failure rate to work with.
+func monitorHTLCEvents(ctx context.Context, monitor *insights.Monitor,
+	lnd *lndclient.LndServices) {
+
+	events, errChan, err := lnd.Router.SubscribeHtlcEvents(ctx)
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			recordHTLCEvent(monitor, event)
+
+		case <-errChan:
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recordHTLCEvent records a single HTLC event against the channel(s) it
+// involved. Each HTLC is counted once, when it resolves: settle and forward
+// fail events count toward the total but are not failures, while forward
+// events are skipped since the same HTLC later settles or fails. Only link
+// failures are attributed as peer-caused, and failures lnd attributes to us
+// or to a downstream hop are not held against the channel's peer.
+func recordHTLCEvent(monitor *insights.Monitor, event *routerrpc.HtlcEvent) {
+	switch e := event.Event.(type) {
+	case *routerrpc.HtlcEvent_SettleEvent, *routerrpc.HtlcEvent_ForwardFailEvent:
+		if event.IncomingChannelId != 0 {
+			monitor.RecordHTLC(event.IncomingChannelId, false)
+		}
+		if event.OutgoingChannelId != 0 {
+			monitor.RecordHTLC(event.OutgoingChannelId, false)
+		}
+
+	case *routerrpc.HtlcEvent_LinkFailEvent:
+		peerCaused := e.LinkFailEvent.WireFailure ==
+			lnrpc.Failure_TEMPORARY_CHANNEL_FAILURE ||
+			e.LinkFailEvent.WireFailure ==
+				lnrpc.Failure_EXPIRY_TOO_SOON ||
+			e.LinkFailEvent.WireFailure ==
+				lnrpc.Failure_UNKNOWN_NEXT_PEER
+
+		if event.IncomingChannelId != 0 {
+			monitor.RecordHTLC(event.IncomingChannelId, peerCaused)
+		}
+		if event.OutgoingChannelId != 0 {
+			monitor.RecordHTLC(event.OutgoingChannelId, peerCaused)
+		}
+	}
+}
+
+// pollForwardingHistory periodically fetches forwarding events that have
+// completed since the last poll and feeds them into monitor.
+func pollForwardingHistory(ctx context.Context, monitor *insights.Monitor,
+	lnd *lndclient.LndServices) {
+
+	ticker := time.NewTicker(forwardingPollInterval)
+	defer ticker.Stop()
+
+	lastTimestamp := time.Now()
+
+	for {
+		select {
+		case <-ticker.C:
+			lastTimestamp = recordForwardingHistory(
+				ctx, monitor, lnd, lastTimestamp,
+			)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recordForwardingHistory fetches forwarding events that completed after
+// since, records them on monitor, and returns the timestamp of the most
+// recent event processed (or since, if there were none). since is
+// exclusive: lnd's StartTime bound is inclusive, so the cursor is advanced
+// by a nanosecond past the last event processed to avoid recording it
+// twice on the next poll. Results are paged via IndexOffset until a
+// partial page confirms there is nothing left to fetch.
+func recordForwardingHistory(ctx context.Context, monitor *insights.Monitor,
+	lnd *lndclient.LndServices, since time.Time) time.Time {
+
+	latest := since
+	var indexOffset uint32
+
+	for {
+		resp, err := lnd.Client.ForwardingHistory(
+			ctx, lndclient.ForwardingHistoryRequest{
+				StartTime:   since.Add(time.Nanosecond),
+				IndexOffset: indexOffset,
+				MaxEvents:   forwardingHistoryPageSize,
+			},
+		)
+		if err != nil {
+			return since
+		}
+
+		for _, event := range resp.Events {
+			monitor.RecordForward(
+				event.ChanIdIn, event.ChanIdOut,
+				uint64(event.AmtMsatIn),
+				uint64(event.AmtMsatOut),
+				uint64(event.FeeMsat),
+			)
+
+			if event.Timestamp.After(latest) {
+				latest = event.Timestamp
+			}
+		}
+
+		if len(resp.Events) < forwardingHistoryPageSize {
+			return latest
+		}
+
+		indexOffset = resp.LastIndexOffset
+	}
+}
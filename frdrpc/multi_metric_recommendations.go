@@ -0,0 +1,97 @@
+package frdrpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/lightninglabs/faraday/insights"
+	"github.com/lightninglabs/faraday/recommend"
+)
+
+// parseMultiMetricRequest parses a multi-metric recommendation request,
+// looking up the recommender registered for each requested metric and
+// converting request-level weights/thresholds into the recommend package's
+// composite config.
+func parseMultiMetricRequest(ctx context.Context, cfg *Config,
+	req *MultiMetricRecommendationsRequest) (*recommend.CompositeConfig,
+	error) {
+
+	if len(req.Metrics) == 0 {
+		return nil, fmt.Errorf("at least one metric is required")
+	}
+
+	weighted := make([]*recommend.WeightedMetric, len(req.Metrics))
+	for i, m := range req.Metrics {
+		id, ok := metricIDs[m.Metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric: %v", m.Metric)
+		}
+
+		recommender, err := recommend.GetRecommender(id)
+		if err != nil {
+			return nil, err
+		}
+
+		direction := recommend.Below
+		if m.Direction == MetricWeight_ABOVE {
+			direction = recommend.Above
+		}
+
+		weighted[i] = &recommend.WeightedMetric{
+			Metric:    recommender,
+			Weight:    float64(m.Weight),
+			Threshold: float64(m.Threshold),
+			Hard:      m.Hard,
+			Direction: direction,
+		}
+	}
+
+	return &recommend.CompositeConfig{
+		ChannelInsights: func() ([]*insights.ChannelInfo, error) {
+			return channelInsights(ctx, cfg, 0)
+		},
+		MinimumMonitored: int64(req.MinimumMonitored),
+		Metrics:          weighted,
+		Cutoff:           float64(req.Cutoff),
+	}, nil
+}
+
+// multiMetricResponse converts a composite recommendation report into its
+// rpc representation.
+func multiMetricResponse(
+	report *recommend.CompositeReport) *MultiMetricRecommendationsResponse {
+
+	resp := &MultiMetricRecommendationsResponse{
+		TotalChannels:      int32(report.TotalChannels),
+		ConsideredChannels: int32(report.ConsideredChannels),
+	}
+
+	for chanPoint, rec := range report.Recommendations {
+		mmRec := &MultiMetricRecommendation{
+			ChanPoint:      chanPoint,
+			Score:          float32(rec.Score),
+			RecommendClose: rec.RecommendClose,
+		}
+
+		for _, sub := range rec.SubScores {
+			mmRec.SubScores = append(
+				mmRec.SubScores, &MetricSubScore{
+					Metric:            sub.MetricID,
+					RawValue:          float32(sub.RawValue),
+					NormalizedValue:   float32(sub.NormalizedValue),
+					ThresholdViolated: sub.ThresholdViolated,
+				},
+			)
+		}
+
+		resp.Recommendations = append(resp.Recommendations, mmRec)
+	}
+
+	sort.SliceStable(resp.Recommendations, func(i, j int) bool {
+		return resp.Recommendations[i].Score <
+			resp.Recommendations[j].Score
+	})
+
+	return resp
+}
@@ -0,0 +1,111 @@
+package frdrpc
+
+import (
+	"fmt"
+
+	"github.com/lightninglabs/faraday/recommend"
+	"github.com/lightninglabs/faraday/recstore"
+)
+
+// maybeSaveRun persists report to the configured recommendation store if
+// save is true and a store is configured. Errors are returned rather than
+// swallowed, since a caller that explicitly asked for a snapshot to be
+// saved should know if it was not.
+func maybeSaveRun(cfg *Config, save bool, metric,
+	requestParams string, report *recommend.Report) error {
+
+	if !save || cfg.RecommendationStore == nil {
+		return nil
+	}
+
+	_, err := cfg.RecommendationStore.SaveRun(
+		metric, requestParams, report,
+	)
+
+	return err
+}
+
+// runSummaryResponse converts a persisted run into the summary form
+// returned by ListRecommendationRuns.
+func runSummaryResponse(run *recstore.Run) *RecommendationRunSummary {
+	return &RecommendationRunSummary{
+		Id:            run.ID,
+		TimestampNs:   run.Timestamp.UnixNano(),
+		Metric:        run.Metric,
+		RequestParams: run.RequestParams,
+	}
+}
+
+// listRecommendationRunsResponse converts the full set of persisted runs
+// into the ListRecommendationRuns response.
+func listRecommendationRunsResponse(
+	runs []*recstore.Run) *ListRecommendationRunsResponse {
+
+	resp := &ListRecommendationRunsResponse{}
+
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, runSummaryResponse(run))
+	}
+
+	return resp
+}
+
+// getRecommendationRunResponse converts a single persisted run, including
+// its full report, into the GetRecommendationRun response.
+func getRecommendationRunResponse(
+	run *recstore.Run) *GetRecommendationRunResponse {
+
+	return &GetRecommendationRunResponse{
+		Run:             runSummaryResponse(run),
+		Recommendations: rpcResponse(run.Report).Recommendations,
+	}
+}
+
+// parseDiffRequest validates a diff request and looks up the two runs it
+// references, returning them ordered oldest first.
+func parseDiffRequest(cfg *Config,
+	req *DiffRecommendationRunsRequest) (older, newer *recstore.Run,
+	err error) {
+
+	if cfg.RecommendationStore == nil {
+		return nil, nil, fmt.Errorf("no recommendation store configured")
+	}
+
+	a, err := cfg.RecommendationStore.GetRun(req.RunIdA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := cfg.RecommendationStore.GetRun(req.RunIdB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a.Timestamp.After(b.Timestamp) {
+		a, b = b, a
+	}
+
+	return a, b, nil
+}
+
+// diffRecommendationRunsResponse converts a set of channel diffs into the
+// DiffRecommendationRuns response.
+func diffRecommendationRunsResponse(
+	diffs []*recstore.ChannelDiff) *DiffRecommendationRunsResponse {
+
+	resp := &DiffRecommendationRunsResponse{}
+
+	for _, d := range diffs {
+		resp.ChannelDiffs = append(resp.ChannelDiffs, &ChannelDiff{
+			ChanPoint:        d.ChanPoint,
+			EnteredCloseSet:  d.EnteredCloseSet,
+			ExitedCloseSet:   d.ExitedCloseSet,
+			PreviousValue:    float32(d.PreviousValue),
+			CurrentValue:     float32(d.CurrentValue),
+			ConsideredBefore: d.ConsideredBefore,
+			ConsideredNow:    d.ConsideredNow,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,143 @@
+package insights
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// FetchFunc retrieves a fresh set of channel insights from the backend.
+type FetchFunc func() ([]*ChannelInfo, error)
+
+// Cache wraps a FetchFunc with a TTL, coalescing concurrent callers into a
+// single backend fetch and optionally serving slightly stale results when
+// a caller opts in. The underlying channel-insight snapshot changes slowly
+// relative to how often a dashboard might poll it, so a short TTL avoids
+// repeatedly re-walking the forwarding history and channel graph.
+type Cache struct {
+	fetch FetchFunc
+	ttl   time.Duration
+
+	group singleflight.Group
+
+	mu        sync.Mutex
+	snapshot  []*ChannelInfo
+	fetchedAt time.Time
+
+	hits   uint64
+	misses uint64
+}
+
+// NewCache creates a Cache that serves the result of fetch for up to ttl
+// before triggering a refresh.
+func NewCache(fetch FetchFunc, ttl time.Duration) *Cache {
+	return &Cache{
+		fetch: fetch,
+		ttl:   ttl,
+	}
+}
+
+// Status is a point in time snapshot of the cache's hit/miss counters and
+// the age of its current snapshot.
+type Status struct {
+	// Hits is the number of Get calls served from the existing
+	// snapshot.
+	Hits uint64
+
+	// Misses is the number of Get calls that triggered a backend fetch.
+	Misses uint64
+
+	// Age is the amount of time that has elapsed since the current
+	// snapshot was fetched. It is zero if no snapshot has been fetched
+	// yet.
+	Age time.Duration
+}
+
+// Status returns the cache's current hit/miss counters and snapshot age.
+func (c *Cache) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var age time.Duration
+	if !c.fetchedAt.IsZero() {
+		age = time.Since(c.fetchedAt)
+	}
+
+	return Status{
+		Hits:   c.hits,
+		Misses: c.misses,
+		Age:    age,
+	}
+}
+
+// Get returns the cached channel insights if the caller has opted into
+// tolerating a stale result via maxStaleness and the current snapshot is
+// within that staleness bound, refreshing them from the backend otherwise.
+// A maxStaleness of zero is the default, not-opted-in case, and always
+// triggers a fresh fetch from the backend rather than falling back to the
+// cache's TTL. The cache's configured TTL acts as an upper bound on the
+// staleness a caller may request, so that MaxStaleness cannot be used to
+// opt into data staler than the cache was configured to ever serve.
+func (c *Cache) Get(maxStaleness time.Duration) ([]*ChannelInfo, error) {
+	if c.ttl > 0 && maxStaleness > c.ttl {
+		maxStaleness = c.ttl
+	}
+
+	c.mu.Lock()
+	fresh := maxStaleness > 0 && c.snapshot != nil &&
+		time.Since(c.fetchedAt) < maxStaleness
+	if fresh {
+		c.hits++
+		snapshot := c.snapshot
+		c.mu.Unlock()
+
+		return snapshot, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Coalesce concurrent misses into a single backend fetch so that a
+	// burst of requests arriving after the cache expires only triggers
+	// one expensive re-scan.
+	v, err, _ := c.group.Do("channel_insights", func() (interface{}, error) {
+		channels, err := c.fetch()
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.snapshot = channels
+		c.fetchedAt = time.Now()
+		c.mu.Unlock()
+
+		return channels, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*ChannelInfo), nil
+}
+
+// BackgroundRefresh starts a goroutine that proactively refreshes the
+// cache every interval, so that callers rarely pay the cost of a cold
+// fetch. It runs until stop is closed.
+func (c *Cache) BackgroundRefresh(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				// Ignore errors here; the next foreground Get
+				// call will surface them to a caller.
+				_, _ = c.Get(0)
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
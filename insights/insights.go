@@ -0,0 +1,58 @@
+// Package insights contains the data types used to describe the historical
+// behaviour of a channel, as gathered from lnd's forwarding history,
+// channel graph and channel event subscriptions. These values feed into
+// the recommend package's close recommendation heuristics.
+package insights
+
+import "time"
+
+// ChannelInfo contains the set of values we know about a channel that are
+// relevant to assessing whether it is worth keeping open.
+type ChannelInfo struct {
+	// ChannelPoint is the funding outpoint of the channel, in
+	// txid:output-index format.
+	ChannelPoint string
+
+	// PubKey is the public key of the peer the channel is with.
+	PubKey string
+
+	// MonitoredSeconds is the amount of time, in seconds, that we have
+	// monitored the channel for.
+	MonitoredSeconds uint64
+
+	// UptimeSeconds is the amount of time, in seconds, that the channel's
+	// peer was online for over the monitored period.
+	UptimeSeconds uint64
+
+	// VolumeIncomingMsat is the total amount, in millisatoshis, forwarded
+	// into our node over this channel.
+	VolumeIncomingMsat uint64
+
+	// VolumeOutgoingMsat is the total amount, in millisatoshis, forwarded
+	// out of our node over this channel.
+	VolumeOutgoingMsat uint64
+
+	// FeesEarnedMsat is the total routing fees, in millisatoshis, earned
+	// on forwards that used this channel.
+	FeesEarnedMsat uint64
+
+	// Capacity is the channel's total capacity in satoshis.
+	Capacity uint64
+
+	// DisconnectCount is the number of times the channel's peer has
+	// disconnected over the monitored period.
+	DisconnectCount uint64
+
+	// HTLCFailCount is the number of HTLCs that failed on this channel
+	// over the monitored period, for reasons attributable to the peer
+	// (for example insufficient balance or expiry issues).
+	HTLCFailCount uint64
+
+	// HTLCTotalCount is the total number of HTLCs that were attempted
+	// over this channel over the monitored period.
+	HTLCTotalCount uint64
+
+	// LifeTime is the amount of time that has elapsed since the channel
+	// was opened.
+	LifeTime time.Duration
+}
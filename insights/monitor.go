@@ -0,0 +1,224 @@
+package insights
+
+import (
+	"sync"
+	"time"
+)
+
+// channelStats accumulates the running totals for a single channel that
+// ChannelInfo cannot be derived from a single point-in-time lnd query:
+// peer uptime, disconnects and HTLC outcomes are only observable by
+// watching events as they happen, and forwarding volume/fees need to be
+// summed across the forwarding history rather than read off the channel
+// itself.
+type channelStats struct {
+	mu sync.Mutex
+
+	peer string
+
+	monitoredSince time.Time
+	onlineSince    *time.Time
+	uptime         time.Duration
+	disconnects    uint64
+
+	htlcTotal uint64
+	htlcFail  uint64
+
+	volumeIncomingMsat uint64
+	volumeOutgoingMsat uint64
+	feesEarnedMsat     uint64
+}
+
+// Snapshot is a point in time read of a channel's accumulated monitoring
+// data.
+type Snapshot struct {
+	MonitoredSeconds   uint64
+	UptimeSeconds      uint64
+	DisconnectCount    uint64
+	HTLCTotalCount     uint64
+	HTLCFailCount      uint64
+	VolumeIncomingMsat uint64
+	VolumeOutgoingMsat uint64
+	FeesEarnedMsat     uint64
+}
+
+// Monitor accumulates per-channel uptime, disconnect, HTLC and forwarding
+// statistics from lnd's event streams, so that a point-in-time RPC call can
+// be enriched with the history needed by the close recommendation
+// heuristics.
+type Monitor struct {
+	mu sync.Mutex
+
+	// channels maps a channel point to its accumulated stats.
+	channels map[string]*channelStats
+
+	// peerChannels maps a peer's public key to the channel points we
+	// have open with it, so that a peer-level online/offline event can
+	// be attributed to every channel sharing that peer.
+	peerChannels map[string]map[string]bool
+
+	// shortChanIDs maps a channel's short channel ID, as used by
+	// forwarding and HTLC events, to its channel point, as used
+	// everywhere else in the monitor.
+	shortChanIDs map[uint64]string
+}
+
+// NewMonitor creates an empty Monitor.
+func NewMonitor() *Monitor {
+	return &Monitor{
+		channels:     make(map[string]*channelStats),
+		peerChannels: make(map[string]map[string]bool),
+		shortChanIDs: make(map[uint64]string),
+	}
+}
+
+// TrackChannel registers a channel with the monitor, so that subsequent
+// events for its peer and short channel ID are attributed to it. It is a
+// no-op if the channel is already tracked.
+func (m *Monitor) TrackChannel(chanPoint string, shortChanID uint64, peer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.channels[chanPoint]; !ok {
+		m.channels[chanPoint] = &channelStats{
+			peer:           peer,
+			monitoredSince: time.Now(),
+		}
+	}
+
+	if m.peerChannels[peer] == nil {
+		m.peerChannels[peer] = make(map[string]bool)
+	}
+	m.peerChannels[peer][chanPoint] = true
+
+	m.shortChanIDs[shortChanID] = chanPoint
+}
+
+// PeerOnline records that peer has come online, starting the uptime clock
+// for every channel we have open with it.
+func (m *Monitor) PeerOnline(peer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for chanPoint := range m.peerChannels[peer] {
+		stats := m.channels[chanPoint]
+
+		stats.mu.Lock()
+		if stats.onlineSince == nil {
+			stats.onlineSince = &now
+		}
+		stats.mu.Unlock()
+	}
+}
+
+// PeerOffline records that peer has gone offline, stopping the uptime
+// clock and incrementing the disconnect count for every channel we have
+// open with it.
+func (m *Monitor) PeerOffline(peer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for chanPoint := range m.peerChannels[peer] {
+		stats := m.channels[chanPoint]
+
+		stats.mu.Lock()
+		if stats.onlineSince != nil {
+			stats.uptime += now.Sub(*stats.onlineSince)
+			stats.onlineSince = nil
+		}
+		stats.disconnects++
+		stats.mu.Unlock()
+	}
+}
+
+// RecordHTLC records the outcome of a single HTLC that resolved over the
+// channel identified by shortChanID. failed should be true only when the
+// failure is attributable to the channel's peer (for example insufficient
+// balance or an expiry violation), not to our own node or a downstream
+// hop. It is a no-op if shortChanID is not tracked.
+func (m *Monitor) RecordHTLC(shortChanID uint64, failed bool) {
+	m.mu.Lock()
+	chanPoint, ok := m.shortChanIDs[shortChanID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	stats := m.channels[chanPoint]
+	m.mu.Unlock()
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	stats.htlcTotal++
+	if failed {
+		stats.htlcFail++
+	}
+}
+
+// RecordForward records a single forwarding event, crediting the incoming
+// channel with the incoming volume and the outgoing channel with the
+// outgoing volume and the fee earned. chanIn and chanOut are the short
+// channel IDs lnd reports the forward against; either is silently ignored
+// if it is not tracked.
+func (m *Monitor) RecordForward(chanIn, chanOut uint64,
+	amtInMsat, amtOutMsat, feeMsat uint64) {
+
+	m.mu.Lock()
+	inPoint, inOK := m.shortChanIDs[chanIn]
+	outPoint, outOK := m.shortChanIDs[chanOut]
+	var in, out *channelStats
+	if inOK {
+		in = m.channels[inPoint]
+	}
+	if outOK {
+		out = m.channels[outPoint]
+	}
+	m.mu.Unlock()
+
+	if in != nil {
+		in.mu.Lock()
+		in.volumeIncomingMsat += amtInMsat
+		in.mu.Unlock()
+	}
+
+	if out != nil {
+		out.mu.Lock()
+		out.volumeOutgoingMsat += amtOutMsat
+		out.feesEarnedMsat += feeMsat
+		out.mu.Unlock()
+	}
+}
+
+// Snapshot returns a point in time read of chanPoint's accumulated
+// monitoring data. It returns the zero Snapshot if the channel is not
+// tracked.
+func (m *Monitor) Snapshot(chanPoint string) Snapshot {
+	m.mu.Lock()
+	stats, ok := m.channels[chanPoint]
+	m.mu.Unlock()
+
+	if !ok {
+		return Snapshot{}
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	uptime := stats.uptime
+	if stats.onlineSince != nil {
+		uptime += time.Since(*stats.onlineSince)
+	}
+
+	return Snapshot{
+		MonitoredSeconds:   uint64(time.Since(stats.monitoredSince).Seconds()),
+		UptimeSeconds:      uint64(uptime.Seconds()),
+		DisconnectCount:    stats.disconnects,
+		HTLCTotalCount:     stats.htlcTotal,
+		HTLCFailCount:      stats.htlcFail,
+		VolumeIncomingMsat: stats.volumeIncomingMsat,
+		VolumeOutgoingMsat: stats.volumeOutgoingMsat,
+		FeesEarnedMsat:     stats.feesEarnedMsat,
+	}
+}
@@ -0,0 +1,253 @@
+package recommend
+
+import (
+	"fmt"
+
+	"github.com/lightninglabs/faraday/insights"
+)
+
+// ThresholdDirection describes which side of a threshold is considered
+// closeable for a single metric within a composite score.
+type ThresholdDirection uint8
+
+const (
+	// Below indicates that values below the threshold are closeable.
+	Below ThresholdDirection = iota
+
+	// Above indicates that values above the threshold are closeable.
+	Above
+)
+
+// WeightedMetric describes a single recommender's contribution to a
+// composite score: the weight it carries in the combined score, a
+// threshold that can independently force a close recommendation, and the
+// direction in which that threshold is considered violated.
+type WeightedMetric struct {
+	// Metric is the recommender used to calculate this component's raw
+	// value for a channel.
+	Metric Recommender
+
+	// Weight is the contribution this metric's normalized value makes
+	// to the composite score. Weights do not need to sum to one; they
+	// are normalized across the set of metrics supplied.
+	Weight float64
+
+	// Threshold is the value which, when violated in Direction, forces
+	// a close recommendation for the channel regardless of its
+	// composite score. A zero value disables the hard threshold, and
+	// the metric only contributes to the composite score.
+	Threshold float64
+
+	// Hard indicates that Threshold is enforced as a hard override. If
+	// Hard is false, Threshold is not evaluated at all.
+	Hard bool
+
+	// Direction is the side of Threshold that is considered closeable.
+	Direction ThresholdDirection
+}
+
+// CompositeConfig describes a composite, multi-metric close
+// recommendation.
+type CompositeConfig struct {
+	// ChannelInsights returns the set of channels the composite score
+	// is calculated over.
+	ChannelInsights func() ([]*insights.ChannelInfo, error)
+
+	// MinimumMonitored is the minimum amount of time a channel must have
+	// been monitored for before it is considered.
+	MinimumMonitored int64
+
+	// Metrics is the set of weighted metrics that make up the composite
+	// score.
+	Metrics []*WeightedMetric
+
+	// Cutoff is the composite score, in [0, 1], above which a channel is
+	// recommended for closure.
+	Cutoff float64
+}
+
+// SubScore holds a single metric's contribution to a channel's composite
+// score.
+type SubScore struct {
+	// MetricID identifies the recommender this sub-score was calculated
+	// with.
+	MetricID string
+
+	// RawValue is the metric's unnormalized value for the channel.
+	RawValue float64
+
+	// NormalizedValue is RawValue scaled to [0, 1] across the channels
+	// considered, oriented so that higher always means "more
+	// closeable".
+	NormalizedValue float64
+
+	// ThresholdViolated is true if this metric's hard threshold, if any,
+	// was violated for the channel.
+	ThresholdViolated bool
+}
+
+// CompositeRecommendation is the result of scoring a single channel against
+// a set of weighted metrics.
+type CompositeRecommendation struct {
+	// Score is the channel's combined, weighted composite score.
+	Score float64
+
+	// SubScores contains the contribution of each configured metric.
+	SubScores []*SubScore
+
+	// RecommendClose is true if the channel's composite score crossed
+	// the configured cutoff, or any hard threshold was violated.
+	RecommendClose bool
+}
+
+// CompositeReport is a high level summary of a composite scoring run.
+type CompositeReport struct {
+	// TotalChannels is the number of channels examined.
+	TotalChannels int
+
+	// ConsideredChannels is the number of channels that met the minimum
+	// monitored period.
+	ConsideredChannels int
+
+	// Recommendations maps a channel point to its composite
+	// recommendation.
+	Recommendations map[string]*CompositeRecommendation
+}
+
+// CompositeRecommendations scores every channel that meets the configured
+// minimum monitored period against the provided weighted metrics,
+// normalizing each metric's raw value across the considered channel set
+// with min-max scaling before combining them into a single weighted score.
+func CompositeRecommendations(cfg *CompositeConfig) (*CompositeReport, error) {
+	if len(cfg.Metrics) == 0 {
+		return nil, fmt.Errorf("at least one metric is required")
+	}
+
+	channels, err := cfg.ChannelInsights()
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel insights: %v",
+			err)
+	}
+
+	minMonitored := float64(cfg.MinimumMonitored)
+	var considered []*insights.ChannelInfo
+	for _, channel := range channels {
+		if float64(channel.MonitoredSeconds) < minMonitored {
+			continue
+		}
+
+		considered = append(considered, channel)
+	}
+
+	// Calculate each metric's raw value for every considered channel, so
+	// that we can normalize across the full set before combining scores.
+	rawValues := make([][]float64, len(cfg.Metrics))
+	for i, wm := range cfg.Metrics {
+		values := make([]float64, len(considered))
+		for j, channel := range considered {
+			value, err := wm.Metric.Value(channel)
+			if err != nil {
+				return nil, fmt.Errorf("could not calculate "+
+					"%v for %v: %v", wm.Metric.ID(),
+					channel.ChannelPoint, err)
+			}
+
+			values[j] = value
+		}
+
+		rawValues[i] = values
+	}
+
+	totalWeight := 0.0
+	for _, wm := range cfg.Metrics {
+		totalWeight += wm.Weight
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("weights must not sum to zero")
+	}
+
+	report := &CompositeReport{
+		TotalChannels:      len(channels),
+		ConsideredChannels: len(considered),
+		Recommendations:    make(map[string]*CompositeRecommendation),
+	}
+
+	for j, channel := range considered {
+		rec := &CompositeRecommendation{
+			SubScores: make([]*SubScore, len(cfg.Metrics)),
+		}
+
+		for i, wm := range cfg.Metrics {
+			raw := rawValues[i][j]
+			normalized := minMaxNormalize(raw, rawValues[i])
+
+			// Orient the normalized value so that higher always
+			// means "more closeable", regardless of which
+			// direction the underlying metric considers
+			// closeable.
+			oriented := normalized
+			if !wm.Metric.CloseableAbove() {
+				oriented = 1 - normalized
+			}
+
+			violated := wm.Hard && wm.Threshold != 0 &&
+				thresholdViolated(raw, wm.Threshold, wm.Direction)
+
+			rec.SubScores[i] = &SubScore{
+				MetricID:          wm.Metric.ID(),
+				RawValue:          raw,
+				NormalizedValue:   oriented,
+				ThresholdViolated: violated,
+			}
+
+			rec.Score += oriented * (wm.Weight / totalWeight)
+
+			if violated {
+				rec.RecommendClose = true
+			}
+		}
+
+		if rec.Score > cfg.Cutoff {
+			rec.RecommendClose = true
+		}
+
+		report.Recommendations[channel.ChannelPoint] = rec
+	}
+
+	return report, nil
+}
+
+// minMaxNormalize scales value to [0, 1] relative to the minimum and
+// maximum of all. If all values are equal there is nothing to distinguish
+// the channels on for this metric, so it returns the neutral value 0.5
+// rather than 0 -- 0 would, once oriented, read as maximally closeable for
+// any CloseableBelow metric and so wrongly dominate the composite score.
+func minMaxNormalize(value float64, all []float64) float64 {
+	min, max := all[0], all[0]
+	for _, v := range all {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	if max == min {
+		return 0.5
+	}
+
+	return (value - min) / (max - min)
+}
+
+// thresholdViolated returns true if value violates threshold in the given
+// direction.
+func thresholdViolated(value, threshold float64,
+	direction ThresholdDirection) bool {
+
+	if direction == Above {
+		return value > threshold
+	}
+
+	return value < threshold
+}
@@ -0,0 +1,300 @@
+package recommend
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lightninglabs/faraday/insights"
+)
+
+// Recommender produces a single numeric value for a channel, along with a
+// description of which side of a threshold is considered closeable. It is
+// the extension point new close recommendation heuristics are added
+// through.
+type Recommender interface {
+	// ID uniquely identifies the recommender, and is used to select it
+	// from the registry and to reference it over rpc.
+	ID() string
+
+	// Description is a short, human readable description of what the
+	// recommender measures.
+	Description() string
+
+	// CloseableAbove returns true if channels with a value above the
+	// chosen threshold should be recommended for closure, and false if
+	// channels below the threshold should be recommended for closure.
+	CloseableAbove() bool
+
+	// Value calculates the recommender's metric for a single channel.
+	Value(channel *insights.ChannelInfo) (float64, error)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Recommender)
+)
+
+// registerRecommender adds a recommender to the global registry. It panics
+// on a duplicate ID, since that indicates a programming error rather than a
+// runtime condition callers can be expected to handle.
+func registerRecommender(r Recommender) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[r.ID()]; ok {
+		panic(fmt.Sprintf("recommender already registered: %v",
+			r.ID()))
+	}
+
+	registry[r.ID()] = r
+}
+
+// GetRecommender looks up a recommender by ID, returning an error if no
+// recommender with that ID has been registered.
+func GetRecommender(id string) (Recommender, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	r, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown recommender: %v", id)
+	}
+
+	return r, nil
+}
+
+// ListRecommenders returns every recommender currently registered.
+func ListRecommenders() []Recommender {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	recommenders := make([]Recommender, 0, len(registry))
+	for _, r := range registry {
+		recommenders = append(recommenders, r)
+	}
+
+	return recommenders
+}
+
+func init() {
+	registerRecommender(uptimeRecommender{})
+	registerRecommender(revenueRecommender{})
+	registerRecommender(incomingVolumeRecommender{})
+	registerRecommender(outgoingVolumeRecommender{})
+	registerRecommender(totalVolumeRecommender{})
+	registerRecommender(feeEarningsPerSatRecommender{})
+	registerRecommender(flowBalanceRecommender{})
+	registerRecommender(peerReliabilityRecommender{})
+}
+
+// UptimeMetric recommends closing channels whose peer has poor uptime.
+var UptimeMetric Recommender = uptimeRecommender{}
+
+// RevenueMetric recommends closing channels that have earned little in
+// routing fees.
+var RevenueMetric Recommender = revenueRecommender{}
+
+// IncomingVolume recommends closing channels that have forwarded little
+// incoming volume.
+var IncomingVolume Recommender = incomingVolumeRecommender{}
+
+// OutgoingVolume recommends closing channels that have forwarded little
+// outgoing volume.
+var OutgoingVolume Recommender = outgoingVolumeRecommender{}
+
+// Volume recommends closing channels that have forwarded little volume in
+// total, in either direction.
+var Volume Recommender = totalVolumeRecommender{}
+
+type uptimeRecommender struct{}
+
+func (uptimeRecommender) ID() string { return "uptime" }
+
+func (uptimeRecommender) Description() string {
+	return "ratio of time the channel's peer was online to total " +
+		"monitored time"
+}
+
+func (uptimeRecommender) CloseableAbove() bool { return false }
+
+func (uptimeRecommender) Value(channel *insights.ChannelInfo) (float64, error) {
+	if channel.MonitoredSeconds == 0 {
+		return 0, fmt.Errorf("channel %v has not been monitored",
+			channel.ChannelPoint)
+	}
+
+	return float64(channel.UptimeSeconds) /
+		float64(channel.MonitoredSeconds), nil
+}
+
+type revenueRecommender struct{}
+
+func (revenueRecommender) ID() string { return "revenue" }
+
+func (revenueRecommender) Description() string {
+	return "total routing fees earned on the channel, in millisatoshis"
+}
+
+func (revenueRecommender) CloseableAbove() bool { return false }
+
+func (revenueRecommender) Value(channel *insights.ChannelInfo) (float64, error) {
+	return float64(channel.FeesEarnedMsat), nil
+}
+
+type incomingVolumeRecommender struct{}
+
+func (incomingVolumeRecommender) ID() string { return "incoming_volume" }
+
+func (incomingVolumeRecommender) Description() string {
+	return "total incoming volume forwarded over the channel, in " +
+		"millisatoshis"
+}
+
+func (incomingVolumeRecommender) CloseableAbove() bool { return false }
+
+func (incomingVolumeRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	return float64(channel.VolumeIncomingMsat), nil
+}
+
+type outgoingVolumeRecommender struct{}
+
+func (outgoingVolumeRecommender) ID() string { return "outgoing_volume" }
+
+func (outgoingVolumeRecommender) Description() string {
+	return "total outgoing volume forwarded over the channel, in " +
+		"millisatoshis"
+}
+
+func (outgoingVolumeRecommender) CloseableAbove() bool { return false }
+
+func (outgoingVolumeRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	return float64(channel.VolumeOutgoingMsat), nil
+}
+
+type totalVolumeRecommender struct{}
+
+func (totalVolumeRecommender) ID() string { return "total_volume" }
+
+func (totalVolumeRecommender) Description() string {
+	return "total volume forwarded over the channel in either " +
+		"direction, in millisatoshis"
+}
+
+func (totalVolumeRecommender) CloseableAbove() bool { return false }
+
+func (totalVolumeRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	return float64(channel.VolumeIncomingMsat +
+		channel.VolumeOutgoingMsat), nil
+}
+
+// feeEarningsPerSatRecommender scores a channel by the routing fees it has
+// earned per satoshi of committed capacity, over the monitored period.
+// Channels that tie up capacity without earning fees proportional to their
+// size are good close candidates.
+type feeEarningsPerSatRecommender struct{}
+
+func (feeEarningsPerSatRecommender) ID() string {
+	return "fee_earnings_per_sat"
+}
+
+func (feeEarningsPerSatRecommender) Description() string {
+	return "routing fees earned per satoshi of committed capacity over " +
+		"the monitored window"
+}
+
+func (feeEarningsPerSatRecommender) CloseableAbove() bool { return false }
+
+func (r feeEarningsPerSatRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	if channel.Capacity == 0 {
+		return 0, fmt.Errorf("channel %v has no capacity",
+			channel.ChannelPoint)
+	}
+
+	return float64(channel.FeesEarnedMsat) / float64(channel.Capacity), nil
+}
+
+// flowBalanceRecommender scores a channel by how lopsided its forwarding
+// flow is, as the ratio of the absolute difference between incoming and
+// outgoing volume to total volume forwarded. A value close to one
+// indicates a channel that is only ever used in one direction, which is a
+// sign that it may be better served by a channel with a peer that offers
+// more balanced flow.
+type flowBalanceRecommender struct{}
+
+func (flowBalanceRecommender) ID() string { return "flow_balance" }
+
+func (flowBalanceRecommender) Description() string {
+	return "ratio of incoming/outgoing volume imbalance to total " +
+		"volume forwarded; high values indicate a stuck " +
+		"unidirectional channel"
+}
+
+func (flowBalanceRecommender) CloseableAbove() bool { return true }
+
+func (flowBalanceRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	total := channel.VolumeIncomingMsat + channel.VolumeOutgoingMsat
+	if total == 0 {
+		return 0, fmt.Errorf("channel %v has not forwarded any "+
+			"volume", channel.ChannelPoint)
+	}
+
+	var diff int64
+	if channel.VolumeIncomingMsat > channel.VolumeOutgoingMsat {
+		diff = int64(channel.VolumeIncomingMsat -
+			channel.VolumeOutgoingMsat)
+	} else {
+		diff = int64(channel.VolumeOutgoingMsat -
+			channel.VolumeIncomingMsat)
+	}
+
+	return float64(diff) / float64(total), nil
+}
+
+// peerReliabilityRecommender scores a channel's peer by how often it
+// disconnects and how often HTLCs fail on the channel for reasons
+// attributable to the peer, relative to the total number of HTLCs
+// attempted. A high score indicates an unreliable peer.
+type peerReliabilityRecommender struct{}
+
+func (peerReliabilityRecommender) ID() string { return "peer_reliability" }
+
+func (peerReliabilityRecommender) Description() string {
+	return "peer disconnect frequency and HTLC failure rate, combined " +
+		"into a single unreliability score"
+}
+
+func (peerReliabilityRecommender) CloseableAbove() bool { return true }
+
+func (peerReliabilityRecommender) Value(
+	channel *insights.ChannelInfo) (float64, error) {
+
+	if channel.MonitoredSeconds == 0 {
+		return 0, fmt.Errorf("channel %v has not been monitored",
+			channel.ChannelPoint)
+	}
+
+	// Disconnects per day gives us a comparable rate regardless of how
+	// long the channel has been monitored for.
+	monitoredDays := float64(channel.MonitoredSeconds) / 86400
+	disconnectRate := float64(channel.DisconnectCount) / monitoredDays
+
+	var htlcFailRate float64
+	if channel.HTLCTotalCount > 0 {
+		htlcFailRate = float64(channel.HTLCFailCount) /
+			float64(channel.HTLCTotalCount)
+	}
+
+	// Combine the two components into a single score, weighting HTLC
+	// failures more heavily since they directly impact payment success.
+	return disconnectRate + (htlcFailRate * 10), nil
+}
@@ -0,0 +1,214 @@
+// Package recommend contains the core logic used to recommend whether a
+// channel should be closed, based on a configurable heuristic applied to
+// the set of insights we have gathered about the channel.
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lightninglabs/faraday/insights"
+)
+
+// DefaultOutlierMultiplier is the default multiplier applied to the
+// interquartile range when identifying outliers.
+const DefaultOutlierMultiplier = 3
+
+// CloseRecommendationConfig provides the functionality required to get
+// close recommendations.
+type CloseRecommendationConfig struct {
+	// ChannelInsights is a function that returns the set of channel
+	// insights that recommendations should be calculated over.
+	ChannelInsights func() ([]*insights.ChannelInfo, error)
+
+	// MinimumMonitored is the minimum amount of time that a channel must
+	// have been monitored for before it is considered for a close
+	// recommendation.
+	MinimumMonitored time.Duration
+
+	// Metric is the recommender used to produce a per-channel value and
+	// closeable decision.
+	Metric Recommender
+}
+
+// Recommendation describes the recommendation made for a single channel,
+// expressed in terms of the recommender's value and whether the channel
+// should be closed.
+type Recommendation struct {
+	// Value is the value calculated for the channel by the recommender
+	// in use.
+	Value float64
+
+	// RecommendClose is true if the channel is recommended for closure.
+	RecommendClose bool
+}
+
+// Report contains a high level summary of the number of channels considered
+// for close recommendations, along with the per channel recommendations
+// that were produced.
+type Report struct {
+	// TotalChannels is the total number of channels that were examined.
+	TotalChannels int
+
+	// ConsideredChannels is the number of channels that were monitored
+	// for long enough to be considered for a recommendation.
+	ConsideredChannels int
+
+	// Recommendations maps a channel point to the recommendation
+	// calculated for it.
+	Recommendations map[string]*Recommendation
+}
+
+// filterChannels returns the subset of channels that have been monitored
+// for at least the minimum monitored period set in the config.
+func filterChannels(channels []*insights.ChannelInfo,
+	minMonitored time.Duration) []*insights.ChannelInfo {
+
+	var considered []*insights.ChannelInfo
+	for _, channel := range channels {
+		monitored := time.Duration(channel.MonitoredSeconds) *
+			time.Second
+
+		if monitored < minMonitored {
+			continue
+		}
+
+		considered = append(considered, channel)
+	}
+
+	return considered
+}
+
+// getRecommendations produces a close recommendation report for the set of
+// channels returned by the config's ChannelInsights function, using the
+// provided closeable function to decide which channels should be flagged
+// for closure.
+func getRecommendations(cfg *CloseRecommendationConfig,
+	closeable func(value float64) bool) (*Report, error) {
+
+	channels, err := cfg.ChannelInsights()
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel insights: %v",
+			err)
+	}
+
+	considered := filterChannels(channels, cfg.MinimumMonitored)
+
+	report := &Report{
+		TotalChannels:      len(channels),
+		ConsideredChannels: len(considered),
+		Recommendations:    make(map[string]*Recommendation),
+	}
+
+	for _, channel := range considered {
+		value, err := cfg.Metric.Value(channel)
+		if err != nil {
+			return nil, fmt.Errorf("could not calculate %v for "+
+				"%v: %v", cfg.Metric.ID(),
+				channel.ChannelPoint, err)
+		}
+
+		report.Recommendations[channel.ChannelPoint] = &Recommendation{
+			Value:          value,
+			RecommendClose: closeable(value),
+		}
+	}
+
+	return report, nil
+}
+
+// ThresholdRecommendations produces close recommendations for every channel
+// whose metric value falls on the closeable side of threshold, as defined
+// by the configured recommender's CloseableAbove value.
+func ThresholdRecommendations(cfg *CloseRecommendationConfig,
+	threshold float64) (*Report, error) {
+
+	closeable := func(value float64) bool {
+		if cfg.Metric.CloseableAbove() {
+			return value > threshold
+		}
+
+		return value < threshold
+	}
+
+	return getRecommendations(cfg, closeable)
+}
+
+// OutlierRecommendations produces close recommendations for every channel
+// whose metric value is an outlier, using the interquartile range of all
+// considered channels' values multiplied by multiplier to define the
+// outlier bounds.
+func OutlierRecommendations(cfg *CloseRecommendationConfig,
+	multiplier float64) (*Report, error) {
+
+	channels, err := cfg.ChannelInsights()
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel insights: %v",
+			err)
+	}
+
+	considered := filterChannels(channels, cfg.MinimumMonitored)
+
+	values := make([]float64, 0, len(considered))
+	for _, channel := range considered {
+		value, err := cfg.Metric.Value(channel)
+		if err != nil {
+			return nil, fmt.Errorf("could not calculate %v for "+
+				"%v: %v", cfg.Metric.ID(),
+				channel.ChannelPoint, err)
+		}
+
+		values = append(values, value)
+	}
+
+	lowerBound, upperBound := outlierBounds(values, multiplier)
+
+	closeable := func(value float64) bool {
+		if cfg.Metric.CloseableAbove() {
+			return value > upperBound
+		}
+
+		return value < lowerBound
+	}
+
+	return getRecommendations(cfg, closeable)
+}
+
+// outlierBounds returns the lower and upper bounds outside of which a value
+// is considered an outlier, calculated as the first and third quartiles of
+// values extended by multiplier times the interquartile range.
+func outlierBounds(values []float64, multiplier float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	q1 := quantile(sorted, 0.25)
+	q3 := quantile(sorted, 0.75)
+	iqr := q3 - q1
+
+	return q1 - multiplier*iqr, q3 + multiplier*iqr
+}
+
+// quantile returns the value at the given quantile (between 0 and 1) of a
+// pre-sorted slice of values, using linear interpolation between closest
+// ranks.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	pos := q * float64(len(sorted)-1)
+	lower := int(pos)
+	upper := lower + 1
+	if upper >= len(sorted) {
+		return sorted[lower]
+	}
+
+	frac := pos - float64(lower)
+	return sorted[lower] + frac*(sorted[upper]-sorted[lower])
+}
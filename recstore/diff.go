@@ -0,0 +1,74 @@
+package recstore
+
+// ChannelDiff describes how a single channel's recommendation changed
+// between two runs.
+type ChannelDiff struct {
+	// ChanPoint is the channel this diff applies to.
+	ChanPoint string
+
+	// EnteredCloseSet is true if the channel was not recommended for
+	// closure in the earlier run, but is in the later one.
+	EnteredCloseSet bool
+
+	// ExitedCloseSet is true if the channel was recommended for closure
+	// in the earlier run, but is not in the later one.
+	ExitedCloseSet bool
+
+	// PreviousValue is the channel's metric value in the earlier run, if
+	// it was considered in that run.
+	PreviousValue float64
+
+	// CurrentValue is the channel's metric value in the later run, if it
+	// is considered in that run.
+	CurrentValue float64
+
+	// ConsideredBefore indicates whether the channel was considered in
+	// the earlier run.
+	ConsideredBefore bool
+
+	// ConsideredNow indicates whether the channel is considered in the
+	// later run.
+	ConsideredNow bool
+}
+
+// Diff compares two recommendation runs and returns, for every channel
+// present in either run, how its recommendation changed between them. The
+// runs are expected to be provided oldest first.
+func Diff(older, newer *Run) []*ChannelDiff {
+	chanPoints := make(map[string]bool)
+	for cp := range older.Report.Recommendations {
+		chanPoints[cp] = true
+	}
+	for cp := range newer.Report.Recommendations {
+		chanPoints[cp] = true
+	}
+
+	diffs := make([]*ChannelDiff, 0, len(chanPoints))
+	for cp := range chanPoints {
+		oldRec, consideredBefore := older.Report.Recommendations[cp]
+		newRec, consideredNow := newer.Report.Recommendations[cp]
+
+		diff := &ChannelDiff{
+			ChanPoint:        cp,
+			ConsideredBefore: consideredBefore,
+			ConsideredNow:    consideredNow,
+		}
+
+		if consideredBefore {
+			diff.PreviousValue = oldRec.Value
+		}
+		if consideredNow {
+			diff.CurrentValue = newRec.Value
+		}
+
+		wasClose := consideredBefore && oldRec.RecommendClose
+		isClose := consideredNow && newRec.RecommendClose
+
+		diff.EnteredCloseSet = !wasClose && isClose
+		diff.ExitedCloseSet = wasClose && !isClose
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
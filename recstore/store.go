@@ -0,0 +1,168 @@
+// Package recstore persists a history of close recommendation runs to a
+// bbolt database, so that operators can see how a channel's recommendation
+// metrics have trended over time rather than only ever acting on a single
+// snapshot.
+package recstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lightninglabs/faraday/recommend"
+	"go.etcd.io/bbolt"
+)
+
+// runsBucket holds one entry per recommendation run, keyed by an
+// auto-incrementing run ID encoded as a big endian uint64.
+var runsBucket = []byte("recommendation-runs")
+
+// Run is a single persisted recommendation run.
+type Run struct {
+	// ID uniquely identifies the run within the store.
+	ID uint64
+
+	// Timestamp is the time the run was executed.
+	Timestamp time.Time
+
+	// Metric identifies the recommender the run was computed with.
+	Metric string
+
+	// RequestParams is a human readable description of the parameters
+	// the run was executed with, for example "threshold=0.95".
+	RequestParams string
+
+	// Report is the full recommendation report produced by the run.
+	Report *recommend.Report
+}
+
+// Store persists recommendation runs to a bbolt database.
+type Store struct {
+	db *bbolt.DB
+}
+
+// NewStore opens (creating if necessary) a bbolt database at dbPath and
+// returns a Store backed by it.
+func NewStore(dbPath string) (*Store, error) {
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{
+		Timeout: time.Second * 5,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not open recommendation "+
+			"store: %v", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize recommendation "+
+			"store: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the store's underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveRun persists a new recommendation run, assigning it the next
+// available run ID.
+func (s *Store) SaveRun(metric, requestParams string,
+	report *recommend.Report) (*Run, error) {
+
+	run := &Run{
+		Timestamp:     time.Now(),
+		Metric:        metric,
+		RequestParams: requestParams,
+		Report:        report,
+	}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		run.ID = id
+
+		value, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(runKey(id), value)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not save recommendation run: %v",
+			err)
+	}
+
+	return run, nil
+}
+
+// ListRuns returns every persisted run, ordered from oldest to newest.
+func (s *Store) ListRuns() ([]*Run, error) {
+	var runs []*Run
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+
+		return bucket.ForEach(func(_, value []byte) error {
+			var run Run
+			if err := json.Unmarshal(value, &run); err != nil {
+				return err
+			}
+
+			runs = append(runs, &run)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list recommendation runs: "+
+			"%v", err)
+	}
+
+	return runs, nil
+}
+
+// GetRun looks up a single persisted run by ID.
+func (s *Store) GetRun(id uint64) (*Run, error) {
+	var run *Run
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+
+		value := bucket.Get(runKey(id))
+		if value == nil {
+			return fmt.Errorf("run %v not found", id)
+		}
+
+		var r Run
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		run = &r
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// runKey encodes a run ID as a big endian uint64, so that bbolt's
+// lexicographic key ordering also orders runs by ID.
+func runKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+
+	return key
+}